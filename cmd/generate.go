@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/IBM/argocd-vault-plugin/pkg/helm"
+	"github.com/IBM/argocd-vault-plugin/pkg/kube"
+	"github.com/IBM/argocd-vault-plugin/pkg/sealedsecret"
+	"github.com/IBM/argocd-vault-plugin/pkg/types"
+	"github.com/IBM/argocd-vault-plugin/pkg/vault"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8yaml "sigs.k8s.io/yaml"
+)
+
+var (
+	generateHelmChart       string
+	generateHelmValuesFiles []string
+	generateHelmSetValues   []string
+	generatePrefix          string
+	generateRoutesConfig    string
+	generateTemplateEngine  string
+	generateKVVersion       string
+	generateSealSecrets     bool
+	generateCert            string
+)
+
+// certCache memoizes the sealed-secrets certificate across every manifest
+// resolved in a single `generate --seal-secrets` invocation.
+var certCache = sealedsecret.NewCertCache()
+
+var generateCmd = &cobra.Command{
+	Use:   "generate <path>",
+	Short: "Resolve <placeholder>s in a directory of manifests, or a rendered Helm chart, against Vault",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runGenerate,
+}
+
+func init() {
+	generateCmd.Flags().StringVar(&generateHelmChart, "helm", "", "render the chart at this path instead of treating <path> as a manifest directory")
+	generateCmd.Flags().StringArrayVarP(&generateHelmValuesFiles, "values", "f", nil, "Helm values file to apply, in order (only with --helm; repeatable)")
+	generateCmd.Flags().StringArrayVar(&generateHelmSetValues, "set", nil, "Helm --set-style value override (only with --helm; repeatable)")
+	generateCmd.Flags().StringVar(&generatePrefix, "prefix", "", "Vault path prefix used when a manifest has no avp_path annotation")
+	generateCmd.Flags().StringVar(&generateRoutesConfig, "routes-config", "", "path to a GVK-to-backend routing config, for splitting CRDs across Vault mounts")
+	generateCmd.Flags().StringVar(&generateTemplateEngine, "template-engine", "", "force every manifest through this engine (currently only \"gotmpl\"); otherwise chosen per-manifest by the "+kube.TemplateEngineAnnotation+" annotation")
+	generateCmd.Flags().StringVar(&generateKVVersion, "kv-version", "", "Vault KV version to use when rendering with the gotmpl engine")
+	generateCmd.Flags().BoolVar(&generateSealSecrets, "seal-secrets", false, "emit SealedSecrets instead of plain Secrets")
+	generateCmd.Flags().StringVar(&generateCert, "cert", "", "sealed-secrets controller certificate, as a file path or URL (required with --seal-secrets)")
+	rootCmd.AddCommand(generateCmd)
+}
+
+// manifestDoc is one manifest read off disk or rendered from a Helm chart.
+// resolved is true for manifests that already went through the gotmpl
+// engine, which operates on raw text and so leaves nothing for Replace to do.
+type manifestDoc struct {
+	data     map[string]interface{}
+	resolved bool
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	backend, err := vault.NewClientFromEnv()
+	if err != nil {
+		return fmt.Errorf("generate: could not build Vault backend: %s", err)
+	}
+
+	router, err := loadRouter(generateRoutesConfig, backend, generatePrefix)
+	if err != nil {
+		return err
+	}
+
+	docs, err := loadDocs(backend, args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		if err := generateOne(cmd, backend, router, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generateOne(cmd *cobra.Command, backend types.Backend, router *kube.Router, doc manifestDoc) error {
+	var tpl *kube.Template
+	if doc.resolved {
+		gvk := (&unstructured.Unstructured{Object: doc.data}).GroupVersionKind()
+		tpl = &kube.Template{Resource: kube.Resource{GVK: gvk, TemplateData: doc.data}}
+	} else {
+		var err error
+		tpl, err = newTemplate(backend, router, doc.data)
+		if err != nil {
+			return fmt.Errorf("generate: could not build Template: %s", err)
+		}
+		if err := tpl.Replace(); err != nil {
+			return fmt.Errorf("generate: could not resolve placeholders: %s", err)
+		}
+	}
+
+	out, err := toYAML(tpl)
+	if err != nil {
+		return fmt.Errorf("generate: could not render resolved manifest: %s", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "---")
+	fmt.Fprint(cmd.OutOrStdout(), out)
+	return nil
+}
+
+// toYAML renders tpl as a plain manifest, or as a SealedSecret when
+// --seal-secrets was passed.
+func toYAML(tpl *kube.Template) (string, error) {
+	if !generateSealSecrets {
+		return tpl.ToYAML()
+	}
+	return tpl.ToSealedYAML(sealedsecret.CertSource{Cache: certCache, Source: generateCert})
+}
+
+// newTemplate builds a Template via router when one is configured (i.e.
+// --routes-config was set), otherwise via backend/generatePrefix directly.
+func newTemplate(backend types.Backend, router *kube.Router, doc map[string]interface{}) (*kube.Template, error) {
+	if router != nil {
+		return kube.NewTemplateFromRouter(doc, router)
+	}
+	return kube.NewTemplate(doc, backend, generatePrefix)
+}
+
+// loadDocs returns the manifests to resolve: a rendered Helm chart when
+// --helm is set, otherwise every YAML document under path.
+func loadDocs(backend types.Backend, path string) ([]manifestDoc, error) {
+	if generateHelmChart != "" {
+		rendered, err := helm.Templates(helm.Options{
+			ChartPath:   generateHelmChart,
+			ValuesFiles: generateHelmValuesFiles,
+			SetValues:   generateHelmSetValues,
+		})
+		if err != nil {
+			return nil, err
+		}
+		docs := make([]manifestDoc, 0, len(rendered))
+		for _, doc := range rendered {
+			docs = append(docs, manifestDoc{data: doc})
+		}
+		return docs, nil
+	}
+	return loadManifestDir(backend, path)
+}
+
+// usesGoTemplate reports whether raw should be rendered with the gotmpl
+// engine: either --template-engine forces it for every manifest, or the
+// manifest itself carries the avp.kubernetes.io/template-engine: gotmpl
+// annotation. The annotation check is a byte scan rather than a YAML parse,
+// since the unrendered file may contain template actions ({{ ... }}) that
+// aren't valid YAML on their own.
+func usesGoTemplate(raw []byte) bool {
+	if generateTemplateEngine == kube.GoTemplateEngine {
+		return true
+	}
+	return bytes.Contains(raw, []byte(kube.TemplateEngineAnnotation)) && bytes.Contains(raw, []byte(kube.GoTemplateEngine))
+}
+
+func loadManifestDir(backend types.Backend, dir string) ([]manifestDoc, error) {
+	var docs []manifestDoc
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if ext := filepath.Ext(p); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		resolved := usesGoTemplate(content)
+		if resolved {
+			rendered, err := kube.NewGoTemplate(p, content, backend, generatePrefix, generateKVVersion).Render()
+			if err != nil {
+				return err
+			}
+			content = rendered
+		}
+
+		for _, raw := range releaseutil.SplitManifests(string(content)) {
+			var data map[string]interface{}
+			if err := k8yaml.Unmarshal([]byte(raw), &data); err != nil {
+				return fmt.Errorf("could not unmarshal %s: %s", p, err)
+			}
+			if len(data) == 0 {
+				continue
+			}
+			docs = append(docs, manifestDoc{data: data, resolved: resolved})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loadManifestDir: could not walk %s: %s", dir, err)
+	}
+	return docs, nil
+}
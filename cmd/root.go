@@ -0,0 +1,14 @@
+// Package cmd implements the argocd-vault-plugin CLI.
+package cmd
+
+import "github.com/spf13/cobra"
+
+var rootCmd = &cobra.Command{
+	Use:   "argocd-vault-plugin",
+	Short: "A Vault plugin for Argo CD",
+}
+
+// Execute runs the CLI, returning the first error encountered.
+func Execute() error {
+	return rootCmd.Execute()
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/IBM/argocd-vault-plugin/pkg/kube"
+	"github.com/IBM/argocd-vault-plugin/pkg/types"
+	"github.com/IBM/argocd-vault-plugin/pkg/vault"
+	k8yaml "sigs.k8s.io/yaml"
+)
+
+// routeConfig is one entry of a --routes-config file: a GVK glob Pattern
+// (see kube.BackendRoute) paired with the Prefix, and optionally the Vault
+// Mount, resources matching it should resolve secrets against.
+type routeConfig struct {
+	Pattern string `json:"pattern"`
+	Prefix  string `json:"prefix"`
+	Mount   string `json:"mount,omitempty"`
+}
+
+// loadRouter builds a *kube.Router from the routes config file at path,
+// falling back to backend/prefix for any GVK that doesn't match a route.
+// A blank path means no routing is configured; loadRouter returns nil so
+// callers can keep using backend/prefix directly.
+func loadRouter(path string, backend types.Backend, prefix string) (*kube.Router, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loadRouter: could not read %s: %s", path, err)
+	}
+
+	var configs []routeConfig
+	if err := k8yaml.Unmarshal(raw, &configs); err != nil {
+		return nil, fmt.Errorf("loadRouter: could not parse %s: %s", path, err)
+	}
+
+	router := &kube.Router{Default: backend, DefaultPrefix: prefix}
+	for _, rc := range configs {
+		routeBackend := backend
+		if rc.Mount != "" {
+			routeBackend, err = vault.NewClientWithMount(rc.Mount)
+			if err != nil {
+				return nil, fmt.Errorf("loadRouter: could not build backend for mount %s: %s", rc.Mount, err)
+			}
+		}
+		router.Routes = append(router.Routes, kube.BackendRoute{
+			Pattern: rc.Pattern,
+			Backend: routeBackend,
+			Prefix:  rc.Prefix,
+		})
+	}
+	return router, nil
+}
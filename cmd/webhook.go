@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/IBM/argocd-vault-plugin/pkg/kube/webhook"
+	"github.com/IBM/argocd-vault-plugin/pkg/vault"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	webhookAddr     string
+	webhookCertFile string
+	webhookKeyFile  string
+	webhookPrefix   string
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Run argocd-vault-plugin as a mutating admission webhook",
+	Args:  cobra.NoArgs,
+	RunE:  runWebhook,
+}
+
+func init() {
+	webhookCmd.Flags().StringVar(&webhookAddr, "addr", ":8443", "address to serve the webhook on")
+	webhookCmd.Flags().StringVar(&webhookCertFile, "cert", "", "TLS certificate file (required)")
+	webhookCmd.Flags().StringVar(&webhookKeyFile, "key", "", "TLS private key file (required)")
+	webhookCmd.Flags().StringVar(&webhookPrefix, "prefix", "", "Vault path prefix used when an object has no avp_path annotation")
+	rootCmd.AddCommand(webhookCmd)
+}
+
+// runWebhook builds a webhook.Server from the in-cluster Kubernetes config
+// and the same Vault backend the generate command uses, then serves until it
+// returns an error.
+func runWebhook(cmd *cobra.Command, args []string) error {
+	backend, err := vault.NewClientFromEnv()
+	if err != nil {
+		return fmt.Errorf("webhook: could not build Vault backend: %s", err)
+	}
+
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("webhook: could not load in-cluster config: %s", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("webhook: could not build Kubernetes client: %s", err)
+	}
+
+	server := webhook.NewServer(webhook.Config{
+		Backend:  backend,
+		Prefix:   webhookPrefix,
+		Addr:     webhookAddr,
+		CertFile: webhookCertFile,
+		KeyFile:  webhookKeyFile,
+		Client:   client,
+	})
+	return server.Run()
+}
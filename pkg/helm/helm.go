@@ -0,0 +1,130 @@
+// Package helm renders a Helm chart into plain Kubernetes manifests so that
+// `<placeholder>` tokens surviving the render can be resolved by
+// kube.NewTemplate, the same way the CLI already handles plain manifest
+// directories. It is wired into the CLI as the `generate --helm <chartdir>` mode.
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	k8yaml "sigs.k8s.io/yaml"
+)
+
+// Options configures a chart render.
+type Options struct {
+	ChartPath   string   // Directory containing Chart.yaml, templates/, values.yaml
+	ValuesFiles []string // Additional -f/--values files, applied in order
+	SetValues   []string // --set overrides, applied after ValuesFiles
+	ReleaseName string   // Release name exposed to the chart as .Release.Name
+	Namespace   string   // Namespace exposed to the chart as .Release.Namespace
+}
+
+// Render loads the chart at opts.ChartPath, merges its default values with
+// opts.ValuesFiles and opts.SetValues, runs the Helm v3 template engine, and
+// returns the rendered manifests as separate YAML documents in Helm's install
+// order (by Kind); the order of manifests of the same Kind is not defined.
+// Hook manifests (e.g. pre-install Jobs) are excluded, matching what `helm
+// template` would install on a first pass.
+func Render(opts Options) ([]string, error) {
+	chart, err := loader.LoadDir(opts.ChartPath)
+	if err != nil {
+		return nil, fmt.Errorf("Render: could not load chart at %s: %s", opts.ChartPath, err)
+	}
+
+	valueOpts := &values.Options{
+		ValueFiles: opts.ValuesFiles,
+		Values:     opts.SetValues,
+	}
+	vals, err := valueOpts.MergeValues(getter.All(cli.New()))
+	if err != nil {
+		return nil, fmt.Errorf("Render: could not merge values for chart %s: %s", chart.Name(), err)
+	}
+
+	releaseOpts := chartutil.ReleaseOptions{
+		Name:      releaseName(opts.ReleaseName, chart.Name()),
+		Namespace: opts.Namespace,
+	}
+	renderVals, err := chartutil.ToRenderValues(chart, vals, releaseOpts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Render: could not build render values for chart %s: %s", chart.Name(), err)
+	}
+
+	rendered, err := engine.Render(chart, renderVals)
+	if err != nil {
+		return nil, fmt.Errorf("Render: could not render chart %s: %s", chart.Name(), err)
+	}
+
+	manifests := releaseutil.SplitManifests(joinManifests(rendered))
+	_, generic, err := releaseutil.SortManifests(manifests, nil, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, fmt.Errorf("Render: could not sort manifests for chart %s: %s", chart.Name(), err)
+	}
+
+	docs := make([]string, 0, len(generic))
+	for _, m := range generic {
+		docs = append(docs, m.Content)
+	}
+	return docs, nil
+}
+
+// joinManifests drops the partials/NOTES.txt-style non-manifest entries that
+// engine.Render produces alongside real templates, then rejoins the rest so
+// releaseutil.SplitManifests can split them back into separate documents.
+func joinManifests(rendered map[string]string) string {
+	var sb strings.Builder
+	for name, content := range rendered {
+		if strings.HasSuffix(name, "NOTES.txt") || strings.TrimSpace(content) == "" {
+			continue
+		}
+		sb.WriteString("---\n# Source: ")
+		sb.WriteString(name)
+		sb.WriteString("\n")
+		sb.WriteString(content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func releaseName(name, fallback string) string {
+	if name != "" {
+		return name
+	}
+	return fallback
+}
+
+// Templates renders the chart like Render, then unmarshals each manifest into
+// the map[string]interface{} shape kube.NewTemplate expects, so a caller can
+// feed rendered docs straight into the existing placeholder pipeline:
+//
+//	docs, err := helm.Templates(opts)
+//	for _, doc := range docs {
+//		tpl, err := kube.NewTemplate(doc, backend, prefix)
+//		...
+//	}
+func Templates(opts Options) ([]map[string]interface{}, error) {
+	manifests, err := Render(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]map[string]interface{}, 0, len(manifests))
+	for _, manifest := range manifests {
+		var doc map[string]interface{}
+		if err := k8yaml.Unmarshal([]byte(manifest), &doc); err != nil {
+			return nil, fmt.Errorf("Templates: could not unmarshal rendered manifest: %s", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,46 @@
+package helm
+
+import "testing"
+
+func TestTemplatesKeepsPlaceholderInStringData(t *testing.T) {
+	docs, err := Templates(Options{ChartPath: "testdata/chart"})
+	if err != nil {
+		t.Fatalf("Templates returned an error: %s", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 rendered manifest, got %d", len(docs))
+	}
+
+	doc := docs[0]
+	if doc["kind"] != "Secret" {
+		t.Fatalf("expected a Secret, got %v", doc["kind"])
+	}
+
+	metadata, _ := doc["metadata"].(map[string]interface{})
+	if metadata["name"] != "example" {
+		t.Errorf("expected chart values to be applied to metadata.name, got %v", metadata["name"])
+	}
+
+	stringData, _ := doc["stringData"].(map[string]interface{})
+	if stringData["password"] != "<password>" {
+		t.Errorf("expected the <placeholder> to survive Helm rendering untouched, got %v", stringData["password"])
+	}
+}
+
+func TestTemplatesAppliesSetOverride(t *testing.T) {
+	docs, err := Templates(Options{
+		ChartPath: "testdata/chart",
+		SetValues: []string{"name=overridden"},
+	})
+	if err != nil {
+		t.Fatalf("Templates returned an error: %s", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 rendered manifest, got %d", len(docs))
+	}
+
+	metadata, _ := docs[0]["metadata"].(map[string]interface{})
+	if metadata["name"] != "overridden" {
+		t.Errorf("expected --set name=overridden to win over values.yaml, got %v", metadata["name"])
+	}
+}
@@ -0,0 +1,152 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"text/template"
+
+	"github.com/IBM/argocd-vault-plugin/pkg/types"
+	"github.com/Masterminds/sprig/v3"
+	k8yaml "sigs.k8s.io/yaml"
+)
+
+// TemplateEngineAnnotation opts a manifest into the Go text/template engine,
+// as an alternative to the default `<placeholder>` substitution. Manifests
+// without this annotation, or with any other value, keep using `<placeholder>`.
+const TemplateEngineAnnotation = "avp.kubernetes.io/template-engine"
+
+// GoTemplateEngine is the TemplateEngineAnnotation value that selects this engine.
+const GoTemplateEngine = "gotmpl"
+
+// GoTemplate renders a manifest's raw YAML as a text/template, with Sprig plus
+// Vault-aware functions available. Unlike `<placeholder>` substitution, which
+// operates on an already-decoded object, GoTemplate works on the manifest's
+// source text, so it must run before the result is handed to NewTemplate.
+type GoTemplate struct {
+	name      string
+	raw       []byte
+	backend   types.Backend
+	prefix    string
+	kvVersion string
+	cache     map[string]map[string]interface{} // memoized backend.GetSecrets results, keyed by "path@kvVersion"
+}
+
+// NewGoTemplate returns a *GoTemplate for raw, named name for error messages
+// (typically the source file path). backend and kvVersion are used by the
+// `vault` and `vaultBase64` template funcs to fetch secrets, which take the
+// full Vault path as given; prefix is used only by the `withPrefix` func.
+func NewGoTemplate(name string, raw []byte, backend types.Backend, prefix, kvVersion string) *GoTemplate {
+	return &GoTemplate{
+		name:      name,
+		raw:       raw,
+		backend:   backend,
+		prefix:    prefix,
+		kvVersion: kvVersion,
+		cache:     make(map[string]map[string]interface{}),
+	}
+}
+
+// Render executes the template and returns the resolved manifest YAML. Parse
+// and execution errors are returned with g.name and the offending line, as
+// produced by text/template, rather than a bare Go template error. If the
+// rendered manifest is a v1/Secret, its `.data` values are validated as
+// base64 so a template author who called `vault` instead of `vaultBase64`
+// inside `data:` gets an error instead of an invalid Secret.
+func (g *GoTemplate) Render() ([]byte, error) {
+	tmpl, err := template.New(g.name).Funcs(sprig.TxtFuncMap()).Funcs(g.funcMap()).Parse(string(g.raw))
+	if err != nil {
+		return nil, fmt.Errorf("Render: could not parse template %s: %s", g.name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("Render: could not execute template %s: %s", g.name, err)
+	}
+
+	rendered := buf.Bytes()
+	if err := validateSecretData(g.name, rendered); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// validateSecretData checks that, if rendered decodes to a core/v1 Secret,
+// every value under .data is valid base64. Non-Secrets, and Secrets with no
+// .data, are left alone.
+func validateSecretData(name string, rendered []byte) error {
+	var header struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := k8yaml.Unmarshal(rendered, &header); err != nil {
+		return fmt.Errorf("Render: could not parse rendered template %s to validate Secret data: %s", name, err)
+	}
+	if header.APIVersion != "v1" || header.Kind != "Secret" {
+		return nil
+	}
+
+	var doc struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := k8yaml.Unmarshal(rendered, &doc); err != nil {
+		return fmt.Errorf("Render: could not parse rendered template %s to validate Secret data: %s", name, err)
+	}
+	for key, value := range doc.Data {
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return fmt.Errorf("Render: data[%s] in %s is not valid base64; use vaultBase64 instead of vault inside a Secret's data block", key, name)
+		}
+	}
+	return nil
+}
+
+// funcMap returns the Vault-aware funcs layered on top of Sprig:
+//   - vault "path" "key"        -> the value of key at path, as-is
+//   - vaultBase64 "path" "key"  -> the value of key at path, base64-encoded
+//   - withPrefix "suffix"       -> g.prefix + "/" + suffix
+//
+// vault and vaultBase64 share g.cache, so a template referencing many keys
+// under the same Vault path only calls backend.GetSecrets once for that path.
+func (g *GoTemplate) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"vault": func(path, key string) (string, error) {
+			return g.lookup(path, key)
+		},
+		"vaultBase64": func(path, key string) (string, error) {
+			value, err := g.lookup(path, key)
+			if err != nil {
+				return "", err
+			}
+			return base64.StdEncoding.EncodeToString([]byte(value)), nil
+		},
+		"withPrefix": func(suffix string) string {
+			return fmt.Sprintf("%s/%s", g.prefix, suffix)
+		},
+	}
+}
+
+func (g *GoTemplate) lookup(path, key string) (string, error) {
+	data, err := g.secrets(path)
+	if err != nil {
+		return "", fmt.Errorf("vault: could not fetch secrets at %s: %s", path, err)
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault: no key %s at path %s", key, path)
+	}
+	return stringify(value), nil
+}
+
+func (g *GoTemplate) secrets(path string) (map[string]interface{}, error) {
+	key := fmt.Sprintf("%s@%s", path, g.kvVersion)
+	if data, ok := g.cache[key]; ok {
+		return data, nil
+	}
+
+	data, err := g.backend.GetSecrets(path, g.kvVersion)
+	if err != nil {
+		return nil, err
+	}
+	g.cache[key] = data
+	return data, nil
+}
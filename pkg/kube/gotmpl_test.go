@@ -0,0 +1,96 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+)
+
+type countingBackend struct {
+	secrets map[string]map[string]interface{}
+	calls   int
+}
+
+func (c *countingBackend) GetSecrets(path, kvVersion string) (map[string]interface{}, error) {
+	c.calls++
+	return c.secrets[path], nil
+}
+
+func TestGoTemplateRenderResolvesStringData(t *testing.T) {
+	backend := &countingBackend{secrets: map[string]map[string]interface{}{
+		"secret/app": {"password": "hunter2"},
+	}}
+
+	raw := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: example
+stringData:
+  password: {{ vault "secret/app" "password" }}
+`)
+
+	out, err := NewGoTemplate("secret.yaml", raw, backend, "secret", "").Render()
+	if err != nil {
+		t.Fatalf("Render returned an error: %s", err)
+	}
+	if !strings.Contains(string(out), "password: hunter2") {
+		t.Errorf("expected rendered stringData.password to be hunter2, got:\n%s", out)
+	}
+}
+
+func TestGoTemplateRenderEnforcesBase64Data(t *testing.T) {
+	backend := &countingBackend{secrets: map[string]map[string]interface{}{
+		"secret/app": {"password": "hunter2"},
+	}}
+
+	raw := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: example
+data:
+  password: {{ vault "secret/app" "password" }}
+`)
+
+	if _, err := NewGoTemplate("secret.yaml", raw, backend, "secret", "").Render(); err == nil {
+		t.Fatal("expected an error for a non-base64 value in .data, got nil")
+	}
+}
+
+func TestGoTemplateRenderAllowsVaultBase64Data(t *testing.T) {
+	backend := &countingBackend{secrets: map[string]map[string]interface{}{
+		"secret/app": {"password": "hunter2"},
+	}}
+
+	raw := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: example
+data:
+  password: {{ vaultBase64 "secret/app" "password" }}
+`)
+
+	if _, err := NewGoTemplate("secret.yaml", raw, backend, "secret", "").Render(); err != nil {
+		t.Fatalf("expected vaultBase64 output to pass .data validation, got: %s", err)
+	}
+}
+
+func TestGoTemplateRenderMemoizesGetSecretsPerPath(t *testing.T) {
+	backend := &countingBackend{secrets: map[string]map[string]interface{}{
+		"secret/app": {"username": "admin", "password": "hunter2"},
+	}}
+
+	raw := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+data:
+  username: {{ vault "secret/app" "username" }}
+  password: {{ vault "secret/app" "password" }}
+`)
+
+	if _, err := NewGoTemplate("configmap.yaml", raw, backend, "secret", "").Render(); err != nil {
+		t.Fatalf("Render returned an error: %s", err)
+	}
+	if backend.calls != 1 {
+		t.Errorf("expected backend.GetSecrets to be called once for a shared path, got %d calls", backend.calls)
+	}
+}
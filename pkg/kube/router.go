@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/IBM/argocd-vault-plugin/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// BackendRoute maps a GVK glob pattern to the Backend/prefix pair that should
+// resolve secrets for matching resources, letting a single argocd-vault-plugin
+// instance split CRDs across multiple Vault mounts (e.g. one per team).
+// Pattern is matched against "<group>/<version>/<kind>" (the core group is
+// rendered as "core"), so "acme.io/*/*" routes every version and Kind in the
+// acme.io group, and "*/v1/Secret" routes every v1 Kind named Secret, the
+// core one and any same-named CRD, whatever its group.
+type BackendRoute struct {
+	Pattern string
+	Backend types.Backend
+	Prefix  string
+}
+
+// Router selects the Backend/prefix pair NewTemplate should use for a given
+// GroupVersionKind, falling back to Default/DefaultPrefix when no Route matches.
+// Routes are evaluated in order; the first match wins.
+type Router struct {
+	Routes        []BackendRoute
+	Default       types.Backend
+	DefaultPrefix string
+}
+
+// Resolve returns the Backend and path prefix to use for gvk.
+func (r *Router) Resolve(gvk schema.GroupVersionKind) (types.Backend, string) {
+	key := routeKey(gvk)
+	for _, route := range r.Routes {
+		if ok, _ := path.Match(route.Pattern, key); ok {
+			return route.Backend, route.Prefix
+		}
+	}
+	return r.Default, r.DefaultPrefix
+}
+
+// NewTemplateFromRouter is NewTemplate, but resolves the Backend and path
+// prefix from router based on the object's GroupVersionKind instead of
+// always using a single Backend/prefix pair.
+func NewTemplateFromRouter(template map[string]interface{}, router *Router) (*Template, error) {
+	obj := &unstructured.Unstructured{}
+	if err := kubeResourceDecoder(&template).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("NewTemplateFromRouter: could not read GroupVersionKind: %s", err)
+	}
+
+	gvk := obj.GroupVersionKind()
+	backend, prefix := router.Resolve(gvk)
+	if backend == nil {
+		return nil, fmt.Errorf("NewTemplateFromRouter: no Backend configured for %s", routeKey(gvk))
+	}
+	return NewTemplate(template, backend, prefix)
+}
+
+func routeKey(gvk schema.GroupVersionKind) string {
+	group := gvk.Group
+	if group == "" {
+		group = coreGroup
+	}
+	return fmt.Sprintf("%s/%s/%s", group, gvk.Version, gvk.Kind)
+}
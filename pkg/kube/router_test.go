@@ -0,0 +1,122 @@
+package kube
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeBackend struct {
+	name    string
+	secrets map[string]map[string]interface{}
+}
+
+func (f *fakeBackend) GetSecrets(path, kvVersion string) (map[string]interface{}, error) {
+	return f.secrets[path], nil
+}
+
+func TestDefaultPath(t *testing.T) {
+	cases := []struct {
+		name string
+		gvk  schema.GroupVersionKind
+		want string
+	}{
+		{"core", schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, "prefix/core/v1/secret"},
+		{"extensions ingress", schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}, "prefix/extensions/v1beta1/ingress"},
+		{"networking ingress", schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, "prefix/networking.k8s.io/v1/ingress"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := defaultPath("prefix", c.gvk); got != c.want {
+				t.Errorf("defaultPath(%v) = %s, want %s", c.gvk, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDefaultPathDistinguishesCollidingKinds(t *testing.T) {
+	extensionsIngress := defaultPath("prefix", schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"})
+	networkingIngress := defaultPath("prefix", schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"})
+
+	if extensionsIngress == networkingIngress {
+		t.Errorf("expected distinct paths for same-named Kinds in different groups, got %s for both", extensionsIngress)
+	}
+}
+
+func TestInterpolatePath(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "acme.io", Version: "v1", Kind: "Widget"}
+	got := interpolatePath("secret/{group}/{version}/{kind}/{namespace}/{name}", gvk, "default", "example")
+	want := "secret/acme.io/v1/widget/default/example"
+	if got != want {
+		t.Errorf("interpolatePath() = %s, want %s", got, want)
+	}
+}
+
+func TestRouterResolveMatchesPattern(t *testing.T) {
+	def := &fakeBackend{name: "default"}
+	acme := &fakeBackend{name: "acme"}
+
+	router := &Router{
+		Default:       def,
+		DefaultPrefix: "secret/default",
+		Routes: []BackendRoute{
+			{Pattern: "acme.io/*/*", Backend: acme, Prefix: "secret/acme"},
+		},
+	}
+
+	backend, prefix := router.Resolve(schema.GroupVersionKind{Group: "acme.io", Version: "v1", Kind: "Widget"})
+	if backend != acme || prefix != "secret/acme" {
+		t.Errorf("expected acme.io GVK to route to acme backend/secret/acme, got %v/%s", backend, prefix)
+	}
+
+	backend, prefix = router.Resolve(schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+	if backend != def || prefix != "secret/default" {
+		t.Errorf("expected core Secret to fall back to default backend/prefix, got %v/%s", backend, prefix)
+	}
+}
+
+func TestRouterResolveFirstMatchWins(t *testing.T) {
+	first := &fakeBackend{name: "first"}
+	second := &fakeBackend{name: "second"}
+
+	router := &Router{
+		Routes: []BackendRoute{
+			{Pattern: "*/v1/Secret", Backend: first, Prefix: "secret/first"},
+			{Pattern: "core/*/*", Backend: second, Prefix: "secret/second"},
+		},
+	}
+
+	backend, _ := router.Resolve(schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+	if backend != first {
+		t.Errorf("expected the first matching route to win, got %v", backend)
+	}
+}
+
+func TestNewTemplateFromRouterUsesMatchingBackend(t *testing.T) {
+	acme := &fakeBackend{secrets: map[string]map[string]interface{}{
+		"secret/acme/acme.io/v1/widget": {"size": "large"},
+	}}
+	router := &Router{
+		Default:       &fakeBackend{},
+		DefaultPrefix: "secret/default",
+		Routes: []BackendRoute{
+			{Pattern: "acme.io/*/*", Backend: acme, Prefix: "secret/acme"},
+		},
+	}
+
+	doc := map[string]interface{}{
+		"apiVersion": "acme.io/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "example"},
+		"spec":       map[string]interface{}{"size": "<size>"},
+	}
+
+	tpl, err := NewTemplateFromRouter(doc, router)
+	if err != nil {
+		t.Fatalf("NewTemplateFromRouter returned an error: %s", err)
+	}
+	if tpl.VaultData["size"] != "large" {
+		t.Errorf("expected VaultData to come from the acme.io route, got %v", tpl.VaultData)
+	}
+}
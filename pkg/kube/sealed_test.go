@@ -0,0 +1,80 @@
+package kube
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	k8yaml "sigs.k8s.io/yaml"
+)
+
+type fakePublicKeyFetcher struct {
+	pub *rsa.PublicKey
+}
+
+func (f fakePublicKeyFetcher) Fetch() (*rsa.PublicKey, error) {
+	return f.pub, nil
+}
+
+func TestToSealedYAMLSealsDataAndStringData(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate RSA key: %s", err)
+	}
+
+	tpl := &Template{Resource{
+		GVK: secretGVK,
+		TemplateData: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata":   map[string]interface{}{"name": "example", "namespace": "default"},
+			"data":       map[string]interface{}{"token": base64.StdEncoding.EncodeToString([]byte("s3cr3t"))},
+			"stringData": map[string]interface{}{"username": "admin"},
+		},
+	}}
+
+	out, err := tpl.ToSealedYAML(fakePublicKeyFetcher{pub: &priv.PublicKey})
+	if err != nil {
+		t.Fatalf("ToSealedYAML returned an error: %s", err)
+	}
+
+	if !strings.Contains(out, "kind: SealedSecret") {
+		t.Fatalf("expected output to be a SealedSecret, got:\n%s", out)
+	}
+
+	var doc map[string]interface{}
+	if err := k8yaml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("could not unmarshal SealedSecret YAML: %s", err)
+	}
+	spec, _ := doc["spec"].(map[string]interface{})
+	encryptedData, _ := spec["encryptedData"].(map[string]interface{})
+
+	for _, key := range []string{"token", "username"} {
+		sealed, ok := encryptedData[key].(string)
+		if !ok || sealed == "" {
+			t.Errorf("expected encryptedData[%s] to be a non-empty sealed value, got %v", key, encryptedData[key])
+		}
+	}
+}
+
+func TestToSealedYAMLFallsBackForNonSecrets(t *testing.T) {
+	tpl := &Template{Resource{
+		GVK: configMapGVK,
+		TemplateData: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "example", "namespace": "default"},
+			"data":       map[string]interface{}{"color": "blue"},
+		},
+	}}
+
+	out, err := tpl.ToSealedYAML(fakePublicKeyFetcher{})
+	if err != nil {
+		t.Fatalf("ToSealedYAML returned an error: %s", err)
+	}
+	if strings.Contains(out, "SealedSecret") {
+		t.Errorf("expected a ConfigMap to pass through ToYAML unchanged, got:\n%s", out)
+	}
+}
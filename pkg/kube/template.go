@@ -6,12 +6,18 @@ import (
 
 	"github.com/IBM/argocd-vault-plugin/pkg/types"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8yaml "sigs.k8s.io/yaml"
 )
 
+// coreGroup is how the annotation interpolation and default path of the core
+// API group (Secret, ConfigMap, ...) are rendered, since GroupVersionKind
+// leaves Group empty for it.
+const coreGroup = "core"
+
 // A Resource is the basis for all Templates
 type Resource struct {
-	Kind              string
+	GVK               schema.GroupVersionKind
 	TemplateData      map[string]interface{} // The template as read from YAML
 	replaceable       bool                   // Whether there are placeholders to replace or not; if false, VaultData will be nil
 	replacementErrors []error                // Any errors encountered in performing replacements
@@ -23,7 +29,11 @@ type Template struct {
 	Resource
 }
 
-// NewTemplate returns a *Template given the template's data, and a VaultType
+// NewTemplate returns a *Template given the template's data, and a VaultType.
+// The Vault path is resolved from the object's GroupVersionKind rather than a
+// bare Kind, so that Kinds which collide across API groups (e.g. Ingress in
+// extensions/v1beta1 vs networking.k8s.io/v1, or same-named CRDs) don't share
+// a path by accident.
 func NewTemplate(template map[string]interface{}, backend types.Backend, prefix string) (*Template, error) {
 	obj := &unstructured.Unstructured{}
 	err := kubeResourceDecoder(&template).Decode(&obj)
@@ -31,11 +41,12 @@ func NewTemplate(template map[string]interface{}, backend types.Backend, prefix
 		return nil, fmt.Errorf("ToYAML: could not convert replaced template into %s: %s", obj.GetKind(), err)
 	}
 
-	path := fmt.Sprintf("%s/%s", prefix, strings.ToLower(obj.GetKind()))
+	gvk := obj.GroupVersionKind()
+	path := defaultPath(prefix, gvk)
 
 	annotations := obj.GetAnnotations()
 	if avpPath, ok := annotations["avp_path"]; ok {
-		path = avpPath
+		path = interpolatePath(avpPath, gvk, obj.GetNamespace(), obj.GetName())
 	}
 
 	var kvVersion string
@@ -55,7 +66,7 @@ func NewTemplate(template map[string]interface{}, backend types.Backend, prefix
 
 	return &Template{
 		Resource{
-			Kind:         obj.GetKind(),
+			GVK:          gvk,
 			TemplateData: template,
 			replaceable:  replaceable,
 			VaultData:    data,
@@ -63,6 +74,43 @@ func NewTemplate(template map[string]interface{}, backend types.Backend, prefix
 	}, nil
 }
 
+// defaultPath builds the Vault path used when no avp_path annotation is
+// present: <prefix>/<group>/<version>/<kind>, with the core group (empty
+// in a GroupVersionKind) rendered as "core".
+func defaultPath(prefix string, gvk schema.GroupVersionKind) string {
+	group := gvk.Group
+	if group == "" {
+		group = coreGroup
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", prefix, group, gvk.Version, strings.ToLower(gvk.Kind))
+}
+
+// interpolatePath expands {group}, {version}, {kind}, {namespace}, and {name}
+// tokens in an avp_path annotation so a single annotation value can be reused
+// across a set of similarly-shaped CRDs.
+func interpolatePath(avpPath string, gvk schema.GroupVersionKind, namespace, name string) string {
+	group := gvk.Group
+	if group == "" {
+		group = coreGroup
+	}
+	replacer := strings.NewReplacer(
+		"{group}", group,
+		"{version}", gvk.Version,
+		"{kind}", strings.ToLower(gvk.Kind),
+		"{namespace}", namespace,
+		"{name}", name,
+	)
+	return replacer.Replace(avpPath)
+}
+
+// secretGVK and configMapGVK are the core/v1 GroupVersionKinds Replace
+// dispatches on specially. Matching the full GVK, rather than a bare Kind
+// string, keeps a CRD named "Secret" or "ConfigMap" from being treated as one.
+var (
+	secretGVK    = schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	configMapGVK = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+)
+
 // Replace will replace the <placeholders> in the Template's data with values from Vault.
 // It will return an aggregrate of any errors encountered during the replacements.
 // For both non-Secret resources and Secrets with <placeholder>'s in `stringData`, the value in Vault is emitted as-is
@@ -75,10 +123,10 @@ func (t *Template) Replace() error {
 		return nil
 	}
 
-	switch t.Kind {
-	case "Secret":
+	switch t.GVK {
+	case secretGVK:
 		return t.secretReplace()
-	case "ConfigMap":
+	case configMapGVK:
 		replacerFunc = configReplacement
 	default:
 		replacerFunc = genericReplacement
@@ -0,0 +1,179 @@
+// Package webhook implements argocd-vault-plugin as a Kubernetes mutating
+// admission webhook, so that placeholders can be resolved on objects applied
+// directly to the cluster rather than only on manifests flowing through Argo CD.
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/IBM/argocd-vault-plugin/pkg/kube"
+	"github.com/IBM/argocd-vault-plugin/pkg/types"
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes"
+)
+
+// OptInAnnotation marks a Namespace as wanting its objects mutated by the webhook.
+// Namespaces without this annotation are passed through unmodified.
+const OptInAnnotation = "avp.kubernetes.io/inject"
+
+var (
+	scheme       = runtime.NewScheme()
+	codecFactory = serializer.NewCodecFactory(scheme)
+	deserializer = codecFactory.UniversalDeserializer()
+)
+
+// Config holds everything the Server needs to resolve placeholders and serve TLS.
+type Config struct {
+	Backend  types.Backend // Backend to fetch secrets from, shared with the CLI
+	Prefix   string        // Vault path prefix, as used by kube.NewTemplate
+	Addr     string        // Address to listen on, e.g. ":8443"
+	CertFile string
+	KeyFile  string
+	// Client is used to look up the OptInAnnotation on the object's Namespace.
+	// A nil Client means every namespace is opted in, which is convenient for tests.
+	Client kubernetes.Interface
+}
+
+// Server is a mutating admission webhook server for argocd-vault-plugin.
+type Server struct {
+	cfg Config
+}
+
+// NewServer returns a *Server ready to Run with the given Config.
+func NewServer(cfg Config) *Server {
+	return &Server{cfg: cfg}
+}
+
+// Run starts the HTTPS server and blocks until it returns an error.
+func (s *Server) Run() error {
+	cert, err := tls.LoadX509KeyPair(s.cfg.CertFile, s.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("Run: could not load TLS keypair: %s", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mutate", s.handleMutate)
+
+	server := &http.Server{
+		Addr:      s.cfg.Addr,
+		Handler:   mux,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	return server.ListenAndServeTLS("", "")
+}
+
+func (s *Server) handleMutate(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("handleMutate: could not read request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	review := &admissionv1.AdmissionReview{}
+	if _, _, err := deserializer.Decode(body, nil, review); err != nil {
+		http.Error(w, fmt.Sprintf("handleMutate: could not decode AdmissionReview: %s", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "handleMutate: AdmissionReview is missing request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = s.mutate(r.Context(), review.Request)
+	review.Response.UID = review.Request.UID
+
+	resp, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("handleMutate: could not marshal AdmissionReview response: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// mutate decodes the admitted object, runs it through the same
+// NewTemplate/Replace steps used by the CLI, marshals the resolved
+// TemplateData to JSON (rather than calling ToYAML), and returns a
+// JSON-patch AdmissionResponse with the resolved object.
+func (s *Server) mutate(ctx context.Context, req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	optedIn, err := s.namespaceOptedIn(ctx, req.Namespace)
+	if err != nil {
+		return admissionError(err)
+	}
+	if !optedIn {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	original := &unstructured.Unstructured{}
+	if err := original.UnmarshalJSON(req.Object.Raw); err != nil {
+		return admissionError(fmt.Errorf("mutate: could not unmarshal admitted object: %s", err))
+	}
+
+	tpl, err := kube.NewTemplate(original.Object, s.cfg.Backend, s.cfg.Prefix)
+	if err != nil {
+		return admissionError(fmt.Errorf("mutate: could not build Template for %s/%s: %s", req.Namespace, req.Name, err))
+	}
+
+	if err := tpl.Replace(); err != nil {
+		return admissionError(fmt.Errorf("mutate: could not replace placeholders in %s/%s: %s", req.Namespace, req.Name, err))
+	}
+
+	resolved, err := json.Marshal(tpl.TemplateData)
+	if err != nil {
+		return admissionError(fmt.Errorf("mutate: could not marshal resolved object: %s", err))
+	}
+
+	patch, err := jsonpatch.CreatePatch(req.Object.Raw, resolved)
+	if err != nil {
+		return admissionError(fmt.Errorf("mutate: could not compute JSON patch: %s", err))
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return admissionError(fmt.Errorf("mutate: could not marshal JSON patch: %s", err))
+	}
+
+	patchType := admissionv1.PatchTypeJSONPatch
+	return &admissionv1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patchBytes,
+		PatchType: &patchType,
+	}
+}
+
+// namespaceOptedIn reports whether namespace carries OptInAnnotation. A nil
+// Client (as used in tests, or single-namespace deployments) opts every
+// namespace in.
+func (s *Server) namespaceOptedIn(ctx context.Context, namespace string) (bool, error) {
+	if s.cfg.Client == nil {
+		return true, nil
+	}
+
+	ns, err := s.cfg.Client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("namespaceOptedIn: could not get Namespace %s: %s", namespace, err)
+	}
+	_, ok := ns.Annotations[OptInAnnotation]
+	return ok, nil
+}
+
+// admissionError surfaces err as a rejected AdmissionResponse with Result.Message set,
+// so Vault fetch failures and the like are visible in `kubectl describe` of the admitted object.
+func admissionError(err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jsonpatch "gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeBackend is a types.Backend that serves a fixed set of secrets regardless
+// of kvVersion, so tests don't need a real Vault.
+type fakeBackend struct {
+	secrets map[string]map[string]interface{}
+}
+
+func (f *fakeBackend) GetSecrets(path, kvVersion string) (map[string]interface{}, error) {
+	return f.secrets[path], nil
+}
+
+func admit(t *testing.T, s *Server, raw []byte) *admissionv1.AdmissionResponse {
+	t.Helper()
+	req := &admissionv1.AdmissionRequest{
+		Namespace: "default",
+		Name:      "example",
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+	return s.mutate(context.Background(), req)
+}
+
+func applyPatch(t *testing.T, original []byte, resp *admissionv1.AdmissionResponse) map[string]interface{} {
+	t.Helper()
+	if !resp.Allowed {
+		t.Fatalf("admission was rejected: %s", resp.Result.Message)
+	}
+
+	var patch jsonpatch.Patch
+	if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+		t.Fatalf("could not unmarshal patch: %s", err)
+	}
+	patched, err := patch.Apply(original)
+	if err != nil {
+		t.Fatalf("could not apply patch: %s", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(patched, &out); err != nil {
+		t.Fatalf("could not unmarshal patched object: %s", err)
+	}
+	return out
+}
+
+func TestMutateSecret(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]map[string]interface{}{
+		"secret/core/v1/secret": {"password": "hunter2"},
+	}}
+	s := NewServer(Config{Backend: backend, Prefix: "secret"})
+
+	raw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"metadata": {"name": "example", "namespace": "default"},
+		"stringData": {"password": "<password>"}
+	}`)
+
+	out := applyPatch(t, raw, admit(t, s, raw))
+	stringData, _ := out["stringData"].(map[string]interface{})
+	if stringData["password"] != "hunter2" {
+		t.Errorf("expected resolved stringData.password to be hunter2, got %v", stringData["password"])
+	}
+}
+
+func TestMutateConfigMap(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]map[string]interface{}{
+		"secret/core/v1/configmap": {"color": "blue"},
+	}}
+	s := NewServer(Config{Backend: backend, Prefix: "secret"})
+
+	raw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "ConfigMap",
+		"metadata": {"name": "example", "namespace": "default"},
+		"data": {"color": "<color>"}
+	}`)
+
+	out := applyPatch(t, raw, admit(t, s, raw))
+	data, _ := out["data"].(map[string]interface{})
+	if data["color"] != "blue" {
+		t.Errorf("expected resolved data.color to be blue, got %v", data["color"])
+	}
+}
+
+func TestMutateCRD(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]map[string]interface{}{
+		"secret/acme.io/v1/widget": {"size": "large"},
+	}}
+	s := NewServer(Config{Backend: backend, Prefix: "secret"})
+
+	raw := []byte(`{
+		"apiVersion": "acme.io/v1",
+		"kind": "Widget",
+		"metadata": {"name": "example", "namespace": "default"},
+		"spec": {"size": "<size>"}
+	}`)
+
+	out := applyPatch(t, raw, admit(t, s, raw))
+	spec, _ := out["spec"].(map[string]interface{})
+	if spec["size"] != "large" {
+		t.Errorf("expected resolved spec.size to be large, got %v", spec["size"])
+	}
+}
+
+func TestHandleMutateRejectsMissingRequest(t *testing.T) {
+	s := NewServer(Config{Backend: &fakeBackend{}, Prefix: "secret"})
+
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+	})
+	if err != nil {
+		t.Fatalf("could not marshal review: %s", err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body))
+	s.handleMutate(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for a request-less AdmissionReview, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestMutateSecretDataIsBase64(t *testing.T) {
+	backend := &fakeBackend{secrets: map[string]map[string]interface{}{
+		"secret/core/v1/secret": {"token": "s3cr3t"},
+	}}
+	s := NewServer(Config{Backend: backend, Prefix: "secret"})
+
+	raw := []byte(`{
+		"apiVersion": "v1",
+		"kind": "Secret",
+		"metadata": {"name": "example", "namespace": "default"},
+		"data": {"token": "<token>"}
+	}`)
+
+	out := applyPatch(t, raw, admit(t, s, raw))
+	data, _ := out["data"].(map[string]interface{})
+	encoded, _ := data["token"].(string)
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("expected data.token to be base64, got %q: %s", encoded, err)
+	}
+	if string(decoded) != "s3cr3t" {
+		t.Errorf("expected decoded data.token to be s3cr3t, got %q", decoded)
+	}
+}
@@ -0,0 +1,161 @@
+// Package sealedsecret implements the Bitnami sealed-secrets hybrid
+// encryption scheme (RSA-OAEP session key + AES-GCM payload), so that
+// argocd-vault-plugin's resolved Secret values can be committed to git as
+// SealedSecrets instead of as plaintext.
+package sealedsecret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// CertCache fetches and memoizes the sealed-secrets controller's public
+// certificate, since it rarely changes and is the same for every Secret in a run.
+type CertCache struct {
+	mu    sync.Mutex
+	certs map[string]*rsa.PublicKey
+}
+
+// NewCertCache returns an empty *CertCache.
+func NewCertCache() *CertCache {
+	return &CertCache{certs: make(map[string]*rsa.PublicKey)}
+}
+
+// Fetch returns the RSA public key at source, which may be an http(s) URL
+// (the sealed-secrets controller's /v1/cert.pem endpoint) or a local file path.
+// Results are cached by source for the lifetime of the CertCache.
+func (c *CertCache) Fetch(source string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pub, ok := c.certs[source]; ok {
+		return pub, nil
+	}
+
+	raw, err := readCert(source)
+	if err != nil {
+		return nil, fmt.Errorf("Fetch: could not read certificate from %s: %s", source, err)
+	}
+
+	pub, err := parsePublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("Fetch: could not parse certificate from %s: %s", source, err)
+	}
+
+	c.certs[source] = pub
+	return pub, nil
+}
+
+func readCert(source string) ([]byte, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+	return ioutil.ReadFile(source)
+}
+
+func parsePublicKey(raw []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("certificate does not contain an RSA public key")
+	}
+	return pub, nil
+}
+
+// PublicKeyFetcher returns the sealed-secrets controller's public key. It is
+// implemented by CertSource, and lets kube.Template.ToSealedYAML depend on
+// fetching a certificate without depending on this package's caching.
+type PublicKeyFetcher interface {
+	Fetch() (*rsa.PublicKey, error)
+}
+
+// CertSource binds a CertCache to a specific certificate file path or URL so
+// it satisfies PublicKeyFetcher.
+type CertSource struct {
+	Cache  *CertCache
+	Source string
+}
+
+// Fetch implements PublicKeyFetcher.
+func (c CertSource) Fetch() (*rsa.PublicKey, error) {
+	return c.Cache.Fetch(c.Source)
+}
+
+// EncryptionLabel is the label bound into the RSA-OAEP wrap of the session
+// key, matching the sealed-secrets controller's default "strict" scope:
+// namespace/name, with no per-key component. A sealed value can only be
+// unsealed into the Secret namespace/name it was sealed for; unlike OAEP,
+// the AES-GCM payload itself is NOT bound to this label (see Seal).
+func EncryptionLabel(namespace, name string) []byte {
+	return []byte(fmt.Sprintf("%s/%s", namespace, name))
+}
+
+// Seal encrypts plaintext for pub using the sealed-secrets hybrid scheme and
+// returns the result base64-encoded, ready to drop into a SealedSecret's
+// spec.encryptedData. label is used only to bind the RSA-OAEP wrap of the
+// session key (matching sealed-secrets' HybridEncrypt); the AES-GCM seal
+// itself uses no additional data, since the real controller's corresponding
+// unseal calls aed.Open with nil AAD.
+func Seal(pub *rsa.PublicKey, label, plaintext []byte) (string, error) {
+	sessionKey := make([]byte, 32)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return "", fmt.Errorf("Seal: could not generate session key: %s", err)
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return "", fmt.Errorf("Seal: could not init AES cipher: %s", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("Seal: could not init AES-GCM: %s", err)
+	}
+
+	// The session key is one-time use, so a zero nonce is safe here.
+	nonce := make([]byte, gcm.NonceSize())
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, sessionKey, label)
+	if err != nil {
+		return "", fmt.Errorf("Seal: could not wrap session key: %s", err)
+	}
+
+	lenPrefix := make([]byte, 2)
+	binary.BigEndian.PutUint16(lenPrefix, uint16(len(wrappedKey)))
+
+	out := make([]byte, 0, len(lenPrefix)+len(wrappedKey)+len(ciphertext))
+	out = append(out, lenPrefix...)
+	out = append(out, wrappedKey...)
+	out = append(out, ciphertext...)
+
+	return base64.StdEncoding.EncodeToString(out), nil
+}
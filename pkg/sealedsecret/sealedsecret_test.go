@@ -0,0 +1,98 @@
+package sealedsecret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+)
+
+// unseal mirrors the real sealed-secrets controller's HybridDecrypt: split
+// off the 2-byte length-prefixed RSA-OAEP-wrapped session key, unwrap it with
+// label, then AES-GCM open the remainder with a zero nonce and nil AAD. A
+// Seal whose output this can't reverse would be rejected by the real
+// controller too.
+func unseal(t *testing.T, priv *rsa.PrivateKey, label []byte, sealed string) []byte {
+	t.Helper()
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		t.Fatalf("could not base64-decode sealed value: %s", err)
+	}
+
+	if len(raw) < 2 {
+		t.Fatalf("sealed value too short: %d bytes", len(raw))
+	}
+	keyLen := int(binary.BigEndian.Uint16(raw[:2]))
+	rest := raw[2:]
+	if len(rest) < keyLen {
+		t.Fatalf("sealed value shorter than its own length prefix claims")
+	}
+	wrappedKey, ciphertext := rest[:keyLen], rest[keyLen:]
+
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, wrappedKey, label)
+	if err != nil {
+		t.Fatalf("could not unwrap session key: %s", err)
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		t.Fatalf("could not init AES cipher: %s", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("could not init AES-GCM: %s", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("could not open AES-GCM payload (as the real sealed-secrets controller would): %s", err)
+	}
+	return plaintext
+}
+
+func TestSealRoundTripsWithControllerCompatibleUnseal(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate RSA key: %s", err)
+	}
+
+	label := EncryptionLabel("default", "example")
+	sealed, err := Seal(&priv.PublicKey, label, []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Seal returned an error: %s", err)
+	}
+
+	plaintext := unseal(t, priv, label, sealed)
+	if string(plaintext) != "hunter2" {
+		t.Errorf("expected round-tripped plaintext to be hunter2, got %q", plaintext)
+	}
+}
+
+func TestSealRejectsWrongLabel(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate RSA key: %s", err)
+	}
+
+	sealed, err := Seal(&priv.PublicKey, EncryptionLabel("default", "example"), []byte("hunter2"))
+	if err != nil {
+		t.Fatalf("Seal returned an error: %s", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		t.Fatalf("could not base64-decode sealed value: %s", err)
+	}
+	keyLen := int(binary.BigEndian.Uint16(raw[:2]))
+	wrappedKey := raw[2 : 2+keyLen]
+
+	if _, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, wrappedKey, EncryptionLabel("default", "other")); err == nil {
+		t.Error("expected unwrapping with a different namespace/name label to fail")
+	}
+}